@@ -0,0 +1,72 @@
+package tagvalue
+
+import "strings"
+
+// Sanity check BIMI indicator records, as published to DNS at
+// default._bimi.<domain> or a selector's equivalent
+
+// Bimi represents a BIMI indicator record, in a format intended for
+// diagnostics and display
+type Bimi struct {
+	V            Field
+	L            Field
+	A            Field
+	Unrecognized map[string]Field
+	ParseError   ParseError
+}
+
+func NewBimiRecord(input string) Bimi {
+	Fields, err := NewMap(input)
+	if err != nil {
+		switch v := err.(type) {
+		case ParseError:
+			return Bimi{ParseError: v}
+		default:
+			return Bimi{ParseError: ParseError{Message: err.Error()}}
+		}
+	}
+
+	ret := Bimi{
+		V:            Fields["v"],
+		L:            Fields["l"],
+		A:            Fields["a"],
+		Unrecognized: Fields,
+	}
+	for _, k := range []string{"v", "l", "a"} {
+		delete(ret.Unrecognized, k)
+	}
+
+	// Annotate version
+	if ret.V.Defined {
+		if ret.V.Value != "BIMI1" {
+			ret.V.addError(`The version field must be <a href="https://tools.wordtothewise.com/rfc/bimi#section-4.1">BIMI1</a>`)
+		}
+		if ret.V.Index != 0 {
+			ret.V.addError(`The version tag must be the <a href="https://tools.wordtothewise.com/rfc/bimi#section-4.1">first tag in the record</a>`)
+		}
+	} else {
+		ret.V.addError(`BIMI records must have a <a href="https://tools.wordtothewise.com/rfc/bimi#section-4.1">version field</a>`)
+	}
+
+	// Annotate location of the logo
+	if ret.L.Defined {
+		if ret.L.Value == "" {
+			ret.L.addInfo(`An empty l= tag means this domain is <a href="https://tools.wordtothewise.com/rfc/bimi#section-4.1">declining to publish an indicator</a>`)
+		} else if !strings.HasPrefix(ret.L.Value, "https://") {
+			ret.L.addError(`The logo location must be an <a href="https://tools.wordtothewise.com/rfc/bimi#section-4.1">https: URL</a>`)
+		} else if !strings.HasSuffix(strings.ToLower(ret.L.Value), ".svg") {
+			ret.L.addWarning(`The logo location should point directly at an <a href="https://tools.wordtothewise.com/rfc/bimi#section-5">SVG Tiny Portable/Secure image</a>`)
+		}
+	}
+
+	// Annotate location of the authority evidence document
+	if ret.A.Defined {
+		if ret.A.Value == "" {
+			ret.A.addInfo(`An empty a= tag means this domain isn't publishing an <a href="https://tools.wordtothewise.com/rfc/bimi#section-4.1">authority evidence document</a>`)
+		} else if !strings.HasPrefix(ret.A.Value, "https://") {
+			ret.A.addError(`The authority evidence location must be an <a href="https://tools.wordtothewise.com/rfc/bimi#section-4.1">https: URL</a>`)
+		}
+	}
+
+	return ret
+}
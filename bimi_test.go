@@ -0,0 +1,23 @@
+package tagvalue
+
+import "testing"
+
+func TestNewBimiRecord(t *testing.T) {
+	rec := NewBimiRecord("v=BIMI1; l=https://example.com/logo.svg; a=https://example.com/evidence.pem")
+	if rec.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", rec.ParseError)
+	}
+	if len(rec.L.Errors) != 0 {
+		t.Errorf("unexpected errors on l=: %v", rec.L.Errors)
+	}
+	if len(rec.A.Errors) != 0 {
+		t.Errorf("unexpected errors on a=: %v", rec.A.Errors)
+	}
+}
+
+func TestNewBimiRecordBadLocation(t *testing.T) {
+	rec := NewBimiRecord("v=BIMI1; l=http://example.com/logo.svg")
+	if len(rec.L.Errors) == 0 {
+		t.Errorf("expected an error for a non-https logo location")
+	}
+}
@@ -1,6 +1,10 @@
 package tagvalue
 
 import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
 	"fmt"
 	"html/template"
 	"strings"
@@ -65,9 +69,9 @@ func NewDkimKey(input string) DkimKey {
 	// Annotate granularity
 	if ret.G.Defined {
 		if ret.G.Value == "*" {
-			ret.V.addWarning(template.HTML(`The granularity field ("g=*") is deprecated in <a href="https://tools.wordtothewise.com/rfc/6376#appendix-C.2">RFC 6376</a>` ))
+			ret.G.addWarning(template.HTML(`The granularity field ("g=*") is deprecated in <a href="https://tools.wordtothewise.com/rfc/6376#appendix-C.2">RFC 6376</a>` ))
 		} else {
-			ret.V.addError(`The granularity field ("g=") is deprecated in <a href="https://tools.wordtothewise.com/rfc/6376#appendix-C.2">RFC 6376</a> and this value will be treated differently by pre-6376 and post-6376 validators`)
+			ret.G.addError(`The granularity field ("g=") is deprecated in <a href="https://tools.wordtothewise.com/rfc/6376#appendix-C.2">RFC 6376</a> and this value will be treated differently by pre-6376 and post-6376 validators`)
 		}
 	}
 
@@ -80,15 +84,125 @@ func NewDkimKey(input string) DkimKey {
 			case "sha1":
 				ret.H.addWarning(template.HTML(`SHA1 is <a href="/rfc/8301#section-3.1">not a trusted hash</a>, mail using it may fail DKIM now or in the future`))
 			default:
-				ret.H.addWarning(fmt.Sprintf("'%s' isn't a hash type I recognize"))
+				ret.H.addWarning(fmt.Sprintf("'%s' isn't a hash type I recognize", algo))
 			}
 		}
 	}
 
 	// Annotate signing algorithm
+	kValue := ret.K.Value
+	if !ret.K.Defined {
+		kValue = "rsa"
+	}
 	if ret.K.Defined {
 		switch ret.K.Value {
+		case "rsa":
+			ret.K.addWarning(template.HTML(`Consider using <a href="https://tools.wordtothewise.com/rfc/8463#section-3">ed25519</a> keys, which are shorter and faster to verify`))
+		case "ed25519":
+		default:
+			ret.K.addError(`'` + ret.K.Value + `' isn't a key type I recognize, expected rsa or ed25519`)
+		}
+	}
+
+	// Annotate, and cross validate against k=, the public key itself
+	if ret.P.Defined {
+		stripped := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+				return -1
+			}
+			return r
+		}, ret.P.Value)
+		if stripped == "" {
+			ret.P.addInfo(template.HTML(`An empty p= tag means this key has been <a href="https://tools.wordtothewise.com/rfc/6376#section-3.6.1">revoked</a>`))
+		} else {
+			der, err := base64.StdEncoding.DecodeString(stripped)
+			if err != nil {
+				ret.P.addError("The public key isn't validly base64 encoded")
+			} else {
+				pub, err := x509.ParsePKIXPublicKey(der)
+				if err != nil {
+					ret.P.addError("The public key doesn't parse as a SubjectPublicKeyInfo")
+				} else {
+					switch key := pub.(type) {
+					case *rsa.PublicKey:
+						if kValue != "rsa" {
+							ret.P.addError(`The public key is an RSA key, which doesn't match k=` + kValue)
+						}
+						bits := key.N.BitLen()
+						switch {
+						case bits < 1024:
+							ret.P.addError(template.HTML(`RSA keys shorter than 1024 bits are <a href="https://tools.wordtothewise.com/rfc/8301#section-3.2">too weak to use</a>`))
+						case bits < 2048:
+							ret.P.addWarning(template.HTML(`RSA keys shorter than 2048 bits are <a href="https://tools.wordtothewise.com/rfc/8301#section-3.2">no longer recommended</a>`))
+						}
+					case ed25519.PublicKey:
+						if kValue != "ed25519" {
+							ret.P.addError(`The public key is an ed25519 key, which doesn't match k=` + kValue)
+						}
+						if len(key) != ed25519.PublicKeySize {
+							ret.P.addError(template.HTML(`An <a href="https://tools.wordtothewise.com/rfc/8463#section-3">ed25519</a> key must be exactly 32 bytes`))
+						}
+					default:
+						ret.P.addError(fmt.Sprintf("'%T' isn't a public key type I recognize", pub))
+					}
+				}
+			}
+		}
+	} else {
+		ret.P.addError(`DKIM key records must have a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.6.1">public key field</a>`)
+	}
+
+	// Annotate flags
+	if ret.T.Defined {
+		for _, flag := range strings.Split(ret.T.Value, ":") {
+			switch flag {
+			case "y", "s":
+			default:
+				ret.T.addWarning(`'` + flag + `' isn't a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.6.1">flag I recognize</a>, unrecognized flags should be ignored`)
+			}
+		}
+	}
+
+	// Annotate service types
+	if ret.S.Defined {
+		for _, service := range strings.Split(ret.S.Value, ":") {
+			switch service {
+			case "*", "email":
+			default:
+				ret.S.addWarning(`'` + service + `' isn't a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.6.1">service type I recognize</a>, unrecognized service types should be ignored`)
+			}
+		}
+	}
 
+	// Annotate notes
+	if ret.N.Defined {
+		if !isValidQuotedPrintable(ret.N.Value) {
+			ret.N.addWarning(template.HTML(`The notes field should be encoded as <a href="https://tools.wordtothewise.com/rfc/6376#section-3.6.1">quoted-printable</a>`))
 		}
 	}
+
+	return ret
+}
+
+// isValidQuotedPrintable reports whether s is printable ASCII with any
+// "=" signs followed by two hex digits, as required of DKIM's n= field
+func isValidQuotedPrintable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '=' {
+			if i+2 >= len(s) || !isHexDigit(s[i+1]) || !isHexDigit(s[i+2]) {
+				return false
+			}
+			i += 2
+			continue
+		}
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
@@ -0,0 +1,53 @@
+package tagvalue
+
+import "testing"
+
+func TestNewDkimKey(t *testing.T) {
+	key := NewDkimKey(key1)
+	if key.ParseError.Message != "" {
+		t.Fatalf("key1 parse failed: %v", key.ParseError)
+	}
+	if len(key.P.Errors) != 1 {
+		t.Fatalf("p=: got %d errors/warnings, want exactly 1 for the 1024-bit RSA key: %v", len(key.P.Errors), key.P.Errors)
+	}
+	if key.P.Errors[0].Severity != "warning" {
+		t.Errorf("p=: got severity %q, want warning for a 1024-bit key (weak but not too weak)", key.P.Errors[0].Severity)
+	}
+}
+
+func TestNewDkimKeyEd25519(t *testing.T) {
+	key := NewDkimKey("v=DKIM1; k=ed25519; p=" + testEd25519Key)
+	if key.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", key.ParseError)
+	}
+	if len(key.K.Errors) != 0 {
+		t.Errorf("unexpected errors on k=: %v", key.K.Errors)
+	}
+	if len(key.P.Errors) != 0 {
+		t.Errorf("unexpected errors on p=: %v", key.P.Errors)
+	}
+}
+
+func TestNewDkimKeyRevoked(t *testing.T) {
+	key := NewDkimKey("v=DKIM1; k=rsa; p=")
+	if len(key.P.Errors) != 1 || key.P.Errors[0].Severity != "info" {
+		t.Errorf("p=: got %#v, want a single info-severity annotation for a revoked key", key.P.Errors)
+	}
+}
+
+func TestNewDkimKeyAlgorithmMismatch(t *testing.T) {
+	key := NewDkimKey("v=DKIM1; k=rsa; p=" + testEd25519Key)
+	found := false
+	for _, e := range key.P.Errors {
+		if e.Severity == "danger" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a danger-severity error when k= doesn't match the key type in p=: %#v", key.P.Errors)
+	}
+}
+
+// testEd25519Key is an arbitrary 32-byte ed25519 SubjectPublicKeyInfo,
+// base64 encoded
+const testEd25519Key = "MCowBQYDK2VwAyEAY4YnxR8gfu9Bm2bWL+coyVdR1zYT5EHqTXY1lLdhWmg="
@@ -0,0 +1,255 @@
+package tagvalue
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// Sanity check DKIM-Signature headers for compliance with RFC 6376
+// section 3.5, plus the algorithm updates in RFC 8301 and RFC 8463
+
+// DkimSignature represents a DKIM-Signature email header, in a format
+// intended for diagnostics and display
+type DkimSignature struct {
+	V            Field
+	A            Field
+	B            Field
+	Bh           Field
+	C            Field
+	D            Field
+	H            Field
+	I            Field
+	L            Field
+	Q            Field
+	S            Field
+	T            Field
+	X            Field
+	Z            Field
+	Unrecognized map[string]Field
+	ParseError   ParseError
+}
+
+func NewDkimSignature(input string) DkimSignature {
+	Fields, err := NewMap(input)
+	if err != nil {
+		switch v := err.(type) {
+		case ParseError:
+			return DkimSignature{ParseError: v}
+		default:
+			return DkimSignature{ParseError: ParseError{Message: err.Error()}}
+		}
+	}
+
+	ret := DkimSignature{
+		V:            Fields["v"],
+		A:            Fields["a"],
+		B:            Fields["b"],
+		Bh:           Fields["bh"],
+		C:            Fields["c"],
+		D:            Fields["d"],
+		H:            Fields["h"],
+		I:            Fields["i"],
+		L:            Fields["l"],
+		Q:            Fields["q"],
+		S:            Fields["s"],
+		T:            Fields["t"],
+		X:            Fields["x"],
+		Z:            Fields["z"],
+		Unrecognized: Fields,
+	}
+	for _, k := range []string{"v", "a", "b", "bh", "c", "d", "h", "i", "l", "q", "s", "t", "x", "z"} {
+		delete(ret.Unrecognized, k)
+	}
+
+	// Annotate version
+	if ret.V.Defined {
+		if ret.V.Value != "1" {
+			ret.V.addError(`The version field must be <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">1</a>`)
+		}
+		if ret.V.Index != 0 {
+			ret.V.addError(`The version tag must be the <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">first tag in the signature</a>`)
+		}
+	} else {
+		ret.V.addError(`DKIM-Signature headers must have a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">version field</a>`)
+	}
+
+	// Annotate signing algorithm
+	if ret.A.Defined {
+		switch ret.A.Value {
+		case "rsa-sha256":
+		case "ed25519-sha256":
+		case "rsa-sha1":
+			ret.A.addWarning(`rsa-sha1 is <a href="https://tools.wordtothewise.com/rfc/8301#section-3.1">no longer a trusted algorithm</a>, mail signed with it may fail DKIM now or in the future`)
+		default:
+			ret.A.addError(`'` + ret.A.Value + `' isn't a signing algorithm I recognize, expected one of rsa-sha256 or <a href="https://tools.wordtothewise.com/rfc/8463#section-2">ed25519-sha256</a>`)
+		}
+	} else {
+		ret.A.addError(`DKIM-Signature headers must have an <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">algorithm field</a>`)
+	}
+
+	// Annotate domain
+	if ret.D.Defined {
+		if !isValidDnsName(ret.D.Value) {
+			ret.D.addError(`'` + ret.D.Value + `' isn't a syntactically valid <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">domain name</a>`)
+		}
+	} else {
+		ret.D.addError(`DKIM-Signature headers must have a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">domain field</a>`)
+	}
+
+	// Annotate selector
+	if ret.S.Defined {
+		if !isValidSelector(ret.S.Value) {
+			ret.S.addError(`'` + ret.S.Value + `' isn't a syntactically valid <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">selector</a>`)
+		}
+	} else {
+		ret.S.addError(`DKIM-Signature headers must have a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">selector field</a>`)
+	}
+
+	// Annotate signed headers
+	if ret.H.Defined {
+		fromSeen := false
+		for _, header := range strings.Split(ret.H.Value, ":") {
+			if strings.EqualFold(strings.TrimSpace(header), "from") {
+				fromSeen = true
+			}
+		}
+		if !fromSeen {
+			ret.H.addError(`The signed headers list must <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">include "from"</a>`)
+		}
+	} else {
+		ret.H.addError(`DKIM-Signature headers must have a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">signed headers field</a>`)
+	}
+
+	// Annotate canonicalization
+	if ret.C.Defined {
+		algos := strings.SplitN(ret.C.Value, "/", 2)
+		if !isValidCanonicalization(algos[0]) || (len(algos) == 2 && !isValidCanonicalization(algos[1])) {
+			ret.C.addError(`The canonicalization field must be <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">simple, relaxed, or algo/algo</a>`)
+		}
+	}
+
+	// Annotate timestamps
+	var signingTime, expireTime int64
+	haveSigningTime, haveExpireTime := false, false
+	if ret.T.Defined {
+		v, err := strconv.ParseUint(ret.T.Value, 10, 63)
+		if err != nil {
+			ret.T.addError(`'` + ret.T.Value + `' isn't a valid <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">unix timestamp</a>`)
+		} else {
+			signingTime, haveSigningTime = int64(v), true
+		}
+	}
+	if ret.X.Defined {
+		v, err := strconv.ParseUint(ret.X.Value, 10, 63)
+		if err != nil {
+			ret.X.addError(`'` + ret.X.Value + `' isn't a valid <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">unix timestamp</a>`)
+		} else {
+			expireTime, haveExpireTime = int64(v), true
+		}
+	}
+	if haveSigningTime && haveExpireTime && expireTime <= signingTime {
+		ret.X.addError(`The expiration time must be <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">later than the signing time</a>`)
+	}
+
+	// Annotate body hash and signature
+	if ret.Bh.Defined {
+		if !isValidBase64(ret.Bh.Value) {
+			ret.Bh.addError(`The body hash isn't <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">validly base64 encoded</a>`)
+		}
+	} else {
+		ret.Bh.addError(`DKIM-Signature headers must have a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">body hash field</a>`)
+	}
+	if ret.B.Defined {
+		if !isValidBase64(ret.B.Value) {
+			ret.B.addError(`The signature isn't <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">validly base64 encoded</a>`)
+		}
+	} else {
+		ret.B.addError(`DKIM-Signature headers must have a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">signature field</a>`)
+	}
+
+	// Annotate body length limit
+	if ret.L.Defined {
+		if _, err := strconv.ParseUint(ret.L.Value, 10, 64); err != nil {
+			ret.L.addError(`The body length must be a <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">non-negative decimal integer</a>`)
+		} else {
+			ret.L.addWarning(`Signing only part of the body <a href="https://tools.wordtothewise.com/rfc/6376#section-8.2">weakens the signature</a>`)
+		}
+	}
+
+	// Annotate query method
+	if ret.Q.Defined {
+		found := false
+		for _, q := range strings.Split(ret.Q.Value, ":") {
+			if q == "dns/txt" {
+				found = true
+			}
+		}
+		if !found {
+			ret.Q.addError(`The query method must include <a href="https://tools.wordtothewise.com/rfc/6376#section-3.5">dns/txt</a>`)
+		}
+	}
+
+	return ret
+}
+
+// isValidDnsName reports whether s looks like a syntactically valid
+// fully qualified domain name
+func isValidDnsName(s string) bool {
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !isValidDnsLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidDnsLabel reports whether s is a valid single DNS label
+func isValidDnsLabel(s string) bool {
+	if s == "" || len(s) > 63 {
+		return false
+	}
+	if s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+		case r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9':
+		case r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isValidSelector reports whether s is a syntactically valid DKIM
+// selector, which shares its grammar with a DNS name
+func isValidSelector(s string) bool {
+	return isValidDnsName(s)
+}
+
+func isValidCanonicalization(s string) bool {
+	return s == "simple" || s == "relaxed"
+}
+
+// isValidBase64 reports whether s decodes as base64, ignoring the
+// folding whitespace the lexer preserves inside long values
+func isValidBase64(s string) bool {
+	stripped := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, s)
+	if stripped == "" {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(stripped)
+	return err == nil
+}
@@ -0,0 +1,64 @@
+package tagvalue
+
+import "testing"
+
+func TestNewDkimSignatureSig1(t *testing.T) {
+	sig := NewDkimSignature(sig1)
+	if sig.ParseError.Message != "" {
+		t.Fatalf("sig1 parse failed: %v", sig.ParseError)
+	}
+	for name, f := range map[string]Field{
+		"v": sig.V, "a": sig.A, "d": sig.D, "s": sig.S,
+		"h": sig.H, "c": sig.C, "bh": sig.Bh, "b": sig.B, "q": sig.Q,
+	} {
+		if len(f.Errors) != 0 {
+			t.Errorf("unexpected errors on %s=: %v", name, f.Errors)
+		}
+	}
+}
+
+func TestNewDkimSignatureSig2(t *testing.T) {
+	sig := NewDkimSignature(sig2)
+	if sig.ParseError.Message != "" {
+		t.Fatalf("sig2 parse failed: %v", sig.ParseError)
+	}
+	if len(sig.H.Errors) != 0 {
+		t.Errorf("unexpected errors on h=, which includes From with mixed case and surrounding spaces: %v", sig.H.Errors)
+	}
+	if len(sig.Bh.Errors) != 0 {
+		t.Errorf("unexpected errors on bh=: %v", sig.Bh.Errors)
+	}
+}
+
+func TestNewDkimSignatureRsaSha1Warning(t *testing.T) {
+	sig := NewDkimSignature("v=1; a=rsa-sha1; d=example.com; s=sel; h=from; bh=MQ==; b=MQ==; c=simple; q=dns/txt")
+	if len(sig.A.Errors) != 1 || sig.A.Errors[0].Severity != "warning" {
+		t.Errorf("a=rsa-sha1: got %#v, want a single warning", sig.A.Errors)
+	}
+}
+
+func TestNewDkimSignatureExpiredBeforeSigned(t *testing.T) {
+	sig := NewDkimSignature("v=1; a=rsa-sha256; d=example.com; s=sel; h=from; bh=MQ==; b=MQ==; t=200; x=100")
+	if len(sig.X.Errors) == 0 {
+		t.Errorf("expected an error when x= is before t=")
+	}
+}
+
+func TestNewDkimSignatureSignedTimestamp(t *testing.T) {
+	sig := NewDkimSignature("v=1; a=rsa-sha256; d=example.com; s=sel; h=from; bh=MQ==; b=MQ==; t=-5")
+	if len(sig.T.Errors) == 0 {
+		t.Errorf("expected an error when t= has a leading sign")
+	}
+
+	sig = NewDkimSignature("v=1; a=rsa-sha256; d=example.com; s=sel; h=from; bh=MQ==; b=MQ==; t=1; x=+5")
+	if len(sig.X.Errors) == 0 {
+		t.Errorf("expected an error when x= has a leading sign")
+	}
+}
+
+func TestNewDkimSignatureMissingFrom(t *testing.T) {
+	sig := NewDkimSignature("v=1; a=rsa-sha256; d=example.com; s=sel; h=to:subject; bh=MQ==; b=MQ==")
+	if len(sig.H.Errors) == 0 {
+		t.Errorf("expected an error when h= doesn't include from")
+	}
+}
@@ -0,0 +1,147 @@
+package tagvalue
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Sanity check DMARC policy records for compliance with RFC 7489
+
+// Dmarc represents a DMARC policy record, as published to DNS at
+// _dmarc.<domain>, in a format intended for diagnostics and display
+type Dmarc struct {
+	V            Field
+	P            Field
+	Sp           Field
+	Rua          Field
+	Ruf          Field
+	Adkim        Field
+	Aspf         Field
+	Pct          Field
+	Fo           Field
+	Rf           Field
+	Ri           Field
+	Unrecognized map[string]Field
+	ParseError   ParseError
+}
+
+func NewDmarcRecord(input string) Dmarc {
+	Fields, err := NewMap(input)
+	if err != nil {
+		switch v := err.(type) {
+		case ParseError:
+			return Dmarc{ParseError: v}
+		default:
+			return Dmarc{ParseError: ParseError{Message: err.Error()}}
+		}
+	}
+
+	ret := Dmarc{
+		V:            Fields["v"],
+		P:            Fields["p"],
+		Sp:           Fields["sp"],
+		Rua:          Fields["rua"],
+		Ruf:          Fields["ruf"],
+		Adkim:        Fields["adkim"],
+		Aspf:         Fields["aspf"],
+		Pct:          Fields["pct"],
+		Fo:           Fields["fo"],
+		Rf:           Fields["rf"],
+		Ri:           Fields["ri"],
+		Unrecognized: Fields,
+	}
+	for _, k := range []string{"v", "p", "sp", "rua", "ruf", "adkim", "aspf", "pct", "fo", "rf", "ri"} {
+		delete(ret.Unrecognized, k)
+	}
+
+	// Annotate version
+	if ret.V.Defined {
+		if ret.V.Value != "DMARC1" {
+			ret.V.addError(`The version field must be <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">DMARC1</a>`)
+		}
+		if ret.V.Index != 0 {
+			ret.V.addError(`The version tag must be the <a href="https://tools.wordtothewise.com/rfc/7489#section-6.4">first tag in the record</a>`)
+		}
+	} else {
+		ret.V.addError(`DMARC records must have a <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">version field</a>`)
+	}
+
+	// Annotate requested policy
+	if ret.P.Defined {
+		if !isValidDmarcPolicy(ret.P.Value) {
+			ret.P.addError(`The policy field must be one of <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">none, quarantine or reject</a>`)
+		}
+	} else {
+		ret.P.addError(`DMARC records must have a <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">policy field</a>`)
+	}
+
+	// Annotate subdomain policy
+	if ret.Sp.Defined && !isValidDmarcPolicy(ret.Sp.Value) {
+		ret.Sp.addError(`The subdomain policy field must be one of <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">none, quarantine or reject</a>`)
+	}
+
+	// Annotate alignment modes
+	if ret.Adkim.Defined && ret.Adkim.Value != "r" && ret.Adkim.Value != "s" {
+		ret.Adkim.addError(`The DKIM alignment mode must be <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">r (relaxed) or s (strict)</a>`)
+	}
+	if ret.Aspf.Defined && ret.Aspf.Value != "r" && ret.Aspf.Value != "s" {
+		ret.Aspf.addError(`The SPF alignment mode must be <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">r (relaxed) or s (strict)</a>`)
+	}
+
+	// Annotate reporting percentage
+	if ret.Pct.Defined {
+		pct, err := strconv.Atoi(ret.Pct.Value)
+		if err != nil || pct < 0 || pct > 100 {
+			ret.Pct.addError(`The pct field must be an <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">integer between 0 and 100</a>`)
+		}
+	}
+
+	// Annotate reporting interval
+	if ret.Ri.Defined {
+		if v, err := strconv.Atoi(ret.Ri.Value); err != nil || v <= 0 {
+			ret.Ri.addError(`The ri field must be a <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">positive number of seconds</a>`)
+		}
+	}
+
+	// Annotate report format
+	if ret.Rf.Defined {
+		for _, f := range strings.Split(ret.Rf.Value, ":") {
+			if f != "afrf" {
+				ret.Rf.addWarning(`'` + f + `' isn't a <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">report format I recognize</a>`)
+			}
+		}
+	}
+
+	// Annotate failure reporting options
+	if ret.Fo.Defined {
+		for _, o := range strings.Split(ret.Fo.Value, ":") {
+			switch o {
+			case "0", "1", "d", "s":
+			default:
+				ret.Fo.addError(`'` + o + `' isn't a <a href="https://tools.wordtothewise.com/rfc/7489#section-6.3">failure reporting option I recognize</a>`)
+			}
+		}
+	}
+
+	// Annotate report URIs
+	if ret.Rua.Defined {
+		for _, uri := range splitURIList(ret.Rua.Value) {
+			if !isValidReportURI(uri) {
+				ret.Rua.addError(`'` + uri + `' isn't a <a href="https://tools.wordtothewise.com/rfc/7489#section-7.1">valid report URI</a>, expected mailto: or https:`)
+			}
+		}
+	}
+	if ret.Ruf.Defined {
+		for _, uri := range splitURIList(ret.Ruf.Value) {
+			if !isValidReportURI(uri) {
+				ret.Ruf.addError(`'` + uri + `' isn't a <a href="https://tools.wordtothewise.com/rfc/7489#section-7.1">valid report URI</a>, expected mailto: or https:`)
+			}
+		}
+	}
+
+	return ret
+}
+
+func isValidDmarcPolicy(s string) bool {
+	return s == "none" || s == "quarantine" || s == "reject"
+}
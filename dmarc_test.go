@@ -0,0 +1,26 @@
+package tagvalue
+
+import "testing"
+
+func TestNewDmarcRecord(t *testing.T) {
+	rec := NewDmarcRecord("v=DMARC1; p=reject; rua=mailto:dmarc@example.com; rf=afrf:x-foo; fo=1")
+	if rec.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", rec.ParseError)
+	}
+	if len(rec.V.Errors) != 0 {
+		t.Errorf("unexpected errors on v=: %v", rec.V.Errors)
+	}
+	if len(rec.P.Errors) != 0 {
+		t.Errorf("unexpected errors on p=: %v", rec.P.Errors)
+	}
+	if len(rec.Rf.Errors) != 1 {
+		t.Errorf("rf=: got %d errors/warnings, want exactly 1 for the unrecognized 'x-foo' format: %v", len(rec.Rf.Errors), rec.Rf.Errors)
+	}
+}
+
+func TestNewDmarcRecordMissingFields(t *testing.T) {
+	rec := NewDmarcRecord("p=none")
+	if len(rec.V.Errors) == 0 {
+		t.Errorf("expected an error for a missing version field")
+	}
+}
@@ -0,0 +1,103 @@
+package tagvalue
+
+import (
+	"io"
+	"strings"
+)
+
+// DefaultFoldColumn is the column FormatOptions folds lines at when
+// FoldColumn is left at zero
+const DefaultFoldColumn = 78
+
+// FormatOptions controls how Format renders a tag-list
+type FormatOptions struct {
+	// FoldColumn is the column to fold long lines at. Zero means
+	// DefaultFoldColumn
+	FoldColumn int
+	// Base64ChunkSize, if non-zero, wraps the values of "b" and "bh"
+	// tags at fixed intervals of this many characters, rather than
+	// folding them at whitespace
+	Base64ChunkSize int
+}
+
+// Format writes items back out as a canonical tag-list, one tag per
+// line, using "\r\n\t" as folding whitespace. It's the inverse of
+// NewTagValue: parsing Format's output recovers the same tags and
+// values, modulo whitespace
+func Format(w io.Writer, items []Item, opts FormatOptions) error {
+	foldColumn := opts.FoldColumn
+	if foldColumn <= 0 {
+		foldColumn = DefaultFoldColumn
+	}
+
+	for i, item := range items {
+		value := item.Value
+		if opts.Base64ChunkSize > 0 && (item.Tag == "b" || item.Tag == "bh") {
+			value = chunkValue(value, opts.Base64ChunkSize)
+		}
+		line := foldLine(item.Tag+"="+value+";", foldColumn)
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+		if i == len(items)-1 {
+			continue
+		}
+		if _, err := io.WriteString(w, "\r\n\t"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chunkValue strips any whitespace already present in value, then
+// re-inserts folding whitespace every size characters
+func chunkValue(value string, size int) string {
+	stripped := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, value)
+
+	var b strings.Builder
+	for i := 0; i < len(stripped); i += size {
+		if i > 0 {
+			b.WriteString("\r\n\t")
+		}
+		end := i + size
+		if end > len(stripped) {
+			end = len(stripped)
+		}
+		b.WriteString(stripped[i:end])
+	}
+	return b.String()
+}
+
+// foldLine greedily wraps line at whitespace once a physical line
+// reaches column characters, folding with "\r\n\t". It only breaks at
+// whitespace, so a run without whitespace longer than column is left
+// unbroken. Lines that already contain a fold, such as chunked base64
+// values, are left alone
+func foldLine(line string, column int) string {
+	if strings.Contains(line, "\r\n") || len(line) <= column {
+		return line
+	}
+
+	var b strings.Builder
+	start := 0
+	lastSpace := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == ' ' || line[i] == '\t' {
+			lastSpace = i
+		}
+		if i-start >= column && lastSpace > start {
+			b.WriteString(line[start:lastSpace])
+			b.WriteString("\r\n\t")
+			start = lastSpace + 1
+			lastSpace = -1
+		}
+	}
+	b.WriteString(line[start:])
+	return b.String()
+}
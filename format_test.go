@@ -0,0 +1,63 @@
+package tagvalue
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	items, err := NewTagValue(sig1)
+	if err != nil {
+		t.Fatalf("sig1 parse failed: %v", err)
+	}
+
+	var b strings.Builder
+	if err := Format(&b, items, FormatOptions{}); err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	got, err := NewTagValue(b.String())
+	if err != nil {
+		t.Fatalf("re-parsing Format output failed: %v\noutput: %q", err, b.String())
+	}
+	if len(got) != len(items) {
+		t.Fatalf("got %d items, want %d", len(got), len(items))
+	}
+	for i := range items {
+		if got[i].Tag != items[i].Tag || got[i].Value != items[i].Value {
+			t.Errorf("item %d: got %#v, want tag/value %q/%q", i, got[i], items[i].Tag, items[i].Value)
+		}
+	}
+}
+
+func TestFormatChunkedBase64RoundTrip(t *testing.T) {
+	items := []Item{
+		{Tag: "v", Value: "1"},
+		{Tag: "bh", Value: "MTIzNDU2Nzg5MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTI="},
+	}
+
+	var b strings.Builder
+	if err := Format(&b, items, FormatOptions{Base64ChunkSize: 16}); err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+
+	got, err := NewTagValue(b.String())
+	if err != nil {
+		t.Fatalf("re-parsing chunked Format output failed: %v\noutput: %q", err, b.String())
+	}
+	if len(got) != len(items) {
+		t.Fatalf("got %d items, want %d", len(got), len(items))
+	}
+	strip := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			switch r {
+			case ' ', '\t', '\r', '\n':
+				return -1
+			}
+			return r
+		}, s)
+	}
+	if strip(got[1].Value) != items[1].Value {
+		t.Errorf("bh value: got %q (stripped %q), want %q", got[1].Value, strip(got[1].Value), items[1].Value)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"html"
 	"html/template"
 	"strings"
 	"unicode/utf8"
@@ -114,6 +113,24 @@ func (f *Field) addInfo(message interface{}, data ...interface{}) {
 	f.annotate("info", message, data)
 }
 
+// AddError attaches a danger-severity annotation to the field. It's
+// exported so that other packages, such as resolver, can extend a
+// field with problems found beyond syntax, such as a fetch of the
+// resource it points to failing
+func (f *Field) AddError(message interface{}, data ...interface{}) {
+	f.addError(message, data...)
+}
+
+// AddWarning attaches a warning-severity annotation to the field
+func (f *Field) AddWarning(message interface{}, data ...interface{}) {
+	f.addWarning(message, data...)
+}
+
+// AddInfo attaches an info-severity annotation to the field
+func (f *Field) AddInfo(message interface{}, data ...interface{}) {
+	f.addInfo(message, data...)
+}
+
 
 // NewMap parses a rfc 6376 tag=value input and returns
 // a map of tag to item
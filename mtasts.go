@@ -0,0 +1,60 @@
+package tagvalue
+
+// Sanity check MTA-STS policy indicator records for compliance with
+// RFC 8461
+
+// MtaSts represents an MTA-STS indicator record, as published to DNS
+// at _mta-sts.<domain>, in a format intended for diagnostics and display
+type MtaSts struct {
+	V            Field
+	Id           Field
+	Unrecognized map[string]Field
+	ParseError   ParseError
+}
+
+func NewMtaStsRecord(input string) MtaSts {
+	Fields, err := NewMap(input)
+	if err != nil {
+		switch v := err.(type) {
+		case ParseError:
+			return MtaSts{ParseError: v}
+		default:
+			return MtaSts{ParseError: ParseError{Message: err.Error()}}
+		}
+	}
+
+	ret := MtaSts{
+		V:            Fields["v"],
+		Id:           Fields["id"],
+		Unrecognized: Fields,
+	}
+	for _, k := range []string{"v", "id"} {
+		delete(ret.Unrecognized, k)
+	}
+
+	// Annotate version
+	if ret.V.Defined {
+		if ret.V.Value != "STSv1" {
+			ret.V.addError(`The version field must be <a href="https://tools.wordtothewise.com/rfc/8461#section-3.1">STSv1</a>`)
+		}
+		if ret.V.Index != 0 {
+			ret.V.addError(`The version tag must be the <a href="https://tools.wordtothewise.com/rfc/8461#section-3.1">first tag in the record</a>`)
+		}
+	} else {
+		ret.V.addError(`MTA-STS records must have a <a href="https://tools.wordtothewise.com/rfc/8461#section-3.1">version field</a>`)
+	}
+
+	// Annotate policy id
+	if ret.Id.Defined {
+		for _, r := range ret.Id.Value {
+			if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+				ret.Id.addError(`The id field must be <a href="https://tools.wordtothewise.com/rfc/8461#section-3.1">alphanumeric</a>`)
+				break
+			}
+		}
+	} else {
+		ret.Id.addError(`MTA-STS records must have an <a href="https://tools.wordtothewise.com/rfc/8461#section-3.1">id field</a>, changed whenever the policy changes`)
+	}
+
+	return ret
+}
@@ -0,0 +1,23 @@
+package tagvalue
+
+import "testing"
+
+func TestNewMtaStsRecord(t *testing.T) {
+	rec := NewMtaStsRecord("v=STSv1; id=20160831085700Z")
+	if rec.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", rec.ParseError)
+	}
+	if len(rec.V.Errors) != 0 {
+		t.Errorf("unexpected errors on v=: %v", rec.V.Errors)
+	}
+	if len(rec.Id.Errors) != 0 {
+		t.Errorf("unexpected errors on id=: %v", rec.Id.Errors)
+	}
+}
+
+func TestNewMtaStsRecordBadId(t *testing.T) {
+	rec := NewMtaStsRecord("v=STSv1; id=not-alphanumeric!")
+	if len(rec.Id.Errors) == 0 {
+		t.Errorf("expected an error for a non-alphanumeric id")
+	}
+}
@@ -0,0 +1,263 @@
+// Package resolver provides a batteries-included way to fetch DKIM,
+// DMARC, MTA-STS and TLS-RPT records from DNS and hand them straight
+// to the tagvalue parsers, without callers needing to talk to DNS
+// themselves.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/wttw/tagvalue"
+)
+
+// DefaultMaxHops is the number of CNAMEs a lookup will follow before
+// giving up
+const DefaultMaxHops = 8
+
+// maxBimiAssetSize caps how much of a BIMI logo or authority evidence
+// document is read, matching the SVG size limit BIMI itself imposes
+const maxBimiAssetSize = 32 * 1024
+
+// Resolver looks up tag=value DNS records for the tagvalue parsers.
+// The zero value is not usable; use NewResolver
+type Resolver struct {
+	Client     *dns.Client
+	HTTPClient *http.Client
+	Servers    []string
+	MaxHops    int
+}
+
+// NewResolver returns a Resolver that queries the given nameservers,
+// each in "host:port" form. If no servers are given the system
+// resolver configuration in /etc/resolv.conf is used
+func NewResolver(servers ...string) (*Resolver, error) {
+	if len(servers) == 0 {
+		conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil {
+			return nil, fmt.Errorf("reading system resolver configuration: %w", err)
+		}
+		for _, s := range conf.Servers {
+			servers = append(servers, s+":"+conf.Port)
+		}
+	}
+	return &Resolver{
+		Client:     &dns.Client{Timeout: 5 * time.Second},
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		Servers:    servers,
+		MaxHops:    DefaultMaxHops,
+	}, nil
+}
+
+// LookupDkimKey fetches the DKIM key published at
+// <selector>._domainkey.<domain> and parses it. warnings reports any
+// CNAMEs that were followed to find the record
+func (r *Resolver) LookupDkimKey(ctx context.Context, selector, domain string) (key tagvalue.DkimKey, warnings []string) {
+	txt, warnings, err := r.lookupTXT(ctx, selector+"._domainkey."+domain)
+	if err != nil {
+		return tagvalue.DkimKey{ParseError: tagvalue.ParseError{Message: err.Error()}}, warnings
+	}
+	return tagvalue.NewDkimKey(txt), warnings
+}
+
+// LookupDmarcRecord fetches the DMARC policy published at
+// _dmarc.<domain> and parses it. warnings reports any CNAMEs that
+// were followed to find the record
+func (r *Resolver) LookupDmarcRecord(ctx context.Context, domain string) (record tagvalue.Dmarc, warnings []string) {
+	txt, warnings, err := r.lookupTXT(ctx, "_dmarc."+domain)
+	if err != nil {
+		return tagvalue.Dmarc{ParseError: tagvalue.ParseError{Message: err.Error()}}, warnings
+	}
+	return tagvalue.NewDmarcRecord(txt), warnings
+}
+
+// LookupMtaStsRecord fetches the MTA-STS indicator published at
+// _mta-sts.<domain> and parses it. warnings reports any CNAMEs that
+// were followed to find the record
+func (r *Resolver) LookupMtaStsRecord(ctx context.Context, domain string) (record tagvalue.MtaSts, warnings []string) {
+	txt, warnings, err := r.lookupTXT(ctx, "_mta-sts."+domain)
+	if err != nil {
+		return tagvalue.MtaSts{ParseError: tagvalue.ParseError{Message: err.Error()}}, warnings
+	}
+	return tagvalue.NewMtaStsRecord(txt), warnings
+}
+
+// LookupTlsRptRecord fetches the TLS-RPT policy published at
+// _smtp._tls.<domain> and parses it. warnings reports any CNAMEs
+// that were followed to find the record
+func (r *Resolver) LookupTlsRptRecord(ctx context.Context, domain string) (record tagvalue.TlsRpt, warnings []string) {
+	txt, warnings, err := r.lookupTXT(ctx, "_smtp._tls."+domain)
+	if err != nil {
+		return tagvalue.TlsRpt{ParseError: tagvalue.ParseError{Message: err.Error()}}, warnings
+	}
+	return tagvalue.NewTlsRptRecord(txt), warnings
+}
+
+// LookupBimiRecord fetches the BIMI indicator published at
+// selector._bimi.<domain>, or default._bimi.<domain> if selector is
+// empty, parses it, and fetches the logo and authority evidence
+// documents it points to so l= and a= can be validated beyond
+// syntax. warnings reports any CNAMEs that were followed to find the
+// record. Full VMC/PKCS7 chain validation of a= isn't implemented;
+// it's only confirmed to be fetchable
+func (r *Resolver) LookupBimiRecord(ctx context.Context, selector, domain string) (record tagvalue.Bimi, warnings []string) {
+	name := "default._bimi." + domain
+	if selector != "" {
+		name = selector + "._bimi." + domain
+	}
+	txt, warnings, err := r.lookupTXT(ctx, name)
+	if err != nil {
+		return tagvalue.Bimi{ParseError: tagvalue.ParseError{Message: err.Error()}}, warnings
+	}
+	record = tagvalue.NewBimiRecord(txt)
+	r.fetchBimiLogo(ctx, &record.L)
+	r.fetchBimiEvidence(ctx, &record.A)
+	return record, warnings
+}
+
+// fetchBimiLogo fetches the SVG that l= points to and annotates l
+// with what it finds
+func (r *Resolver) fetchBimiLogo(ctx context.Context, l *tagvalue.Field) {
+	if !l.Defined || l.Value == "" {
+		return
+	}
+	body, contentType, err := r.fetchURL(ctx, l.Value)
+	if err != nil {
+		l.AddError(fmt.Sprintf("fetching the logo failed: %v", err))
+		return
+	}
+	if !strings.Contains(contentType, "image/svg+xml") {
+		l.AddWarning(fmt.Sprintf("the logo's Content-Type is %q, expected image/svg+xml", contentType))
+	}
+	if !looksLikeBimiSvg(body) {
+		l.AddError(`the logo doesn't look like a well-formed <a href="https://tools.wordtothewise.com/rfc/bimi#section-5">SVG Tiny Portable/Secure image</a>`)
+	}
+}
+
+// fetchBimiEvidence fetches the document that a= points to and
+// annotates a with what it finds
+func (r *Resolver) fetchBimiEvidence(ctx context.Context, a *tagvalue.Field) {
+	if !a.Defined || a.Value == "" {
+		return
+	}
+	if _, _, err := r.fetchURL(ctx, a.Value); err != nil {
+		a.AddError(fmt.Sprintf("fetching the authority evidence document failed: %v", err))
+	}
+}
+
+// looksLikeBimiSvg does a shallow sanity check that body is an SVG
+// document without the scripting BIMI's SVG Tiny Portable/Secure
+// profile forbids. It isn't a substitute for full SVG-TinyPS
+// validation
+func looksLikeBimiSvg(body []byte) bool {
+	s := string(body)
+	return strings.Contains(s, "<svg") && !strings.Contains(s, "<script")
+}
+
+// fetchURL retrieves url, capping how much of the body is read at
+// maxBimiAssetSize
+func (r *Resolver) fetchURL(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBimiAssetSize))
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// lookupTXT fetches the TXT rdata published at name, following CNAME
+// chains up to MaxHops hops, and concatenates multi-string rdata per
+// RFC 6376 section 3.6.2.2. It's an error for a name to publish more
+// than one TXT record. Each CNAME followed is reported as a warning,
+// since it means the record wasn't published directly at name
+func (r *Resolver) lookupTXT(ctx context.Context, name string) (string, []string, error) {
+	name = dns.Fqdn(name)
+	var warnings []string
+	for hop := 0; ; hop++ {
+		if hop >= r.MaxHops {
+			return "", warnings, fmt.Errorf("too many CNAME hops looking up %s", name)
+		}
+
+		msg := new(dns.Msg)
+		msg.SetQuestion(name, dns.TypeTXT)
+
+		resp, err := r.exchange(ctx, msg)
+		if err != nil {
+			return "", warnings, fmt.Errorf("looking up %s: %w", name, err)
+		}
+
+		switch resp.Rcode {
+		case dns.RcodeSuccess:
+		case dns.RcodeNameError:
+			return "", warnings, fmt.Errorf("%s does not exist (NXDOMAIN)", name)
+		default:
+			return "", warnings, fmt.Errorf("looking up %s: %s", name, dns.RcodeToString[resp.Rcode])
+		}
+
+		var txts []*dns.TXT
+		var cname *dns.CNAME
+		for _, rr := range resp.Answer {
+			switch v := rr.(type) {
+			case *dns.TXT:
+				txts = append(txts, v)
+			case *dns.CNAME:
+				cname = v
+				warnings = append(warnings, fmt.Sprintf("%s is a CNAME to %s", v.Hdr.Name, v.Target))
+			}
+		}
+
+		switch {
+		case len(txts) > 1:
+			return "", warnings, fmt.Errorf("%s has more than one TXT record", name)
+		case len(txts) == 1:
+			return strings.Join(txts[0].Txt, ""), warnings, nil
+		case cname != nil:
+			name = cname.Target
+			continue
+		default:
+			return "", warnings, fmt.Errorf("%s has no TXT record", name)
+		}
+	}
+}
+
+// exchange sends msg to each configured server in turn, retrying over
+// TCP if the UDP response comes back truncated
+func (r *Resolver) exchange(ctx context.Context, msg *dns.Msg) (*dns.Msg, error) {
+	var lastErr error
+	for _, server := range r.Servers {
+		resp, _, err := r.Client.ExchangeContext(ctx, msg, server)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.Truncated {
+			tcp := &dns.Client{Net: "tcp", Timeout: r.Client.Timeout}
+			resp, _, err = tcp.ExchangeContext(ctx, msg, server)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		return resp, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no nameservers configured")
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,226 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// startFakeServer starts a UDP DNS server on 127.0.0.1 that answers
+// TXT queries for name with the given rdata, and returns its address
+// and a func to shut it down
+func startFakeServer(t *testing.T, name string, rdata []string) (addr string, stop func()) {
+	t.Helper()
+
+	return startFakeServerFunc(t, name, func(w dns.ResponseWriter, r *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(r)
+		if r.Question[0].Qtype == dns.TypeTXT {
+			msg.Answer = append(msg.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+				Txt: rdata,
+			})
+		}
+		w.WriteMsg(msg)
+	})
+}
+
+// startFakeServerFunc starts a UDP DNS server on 127.0.0.1 that answers
+// queries for name with handler, and returns its address and a func to
+// shut it down
+func startFakeServerFunc(t *testing.T, name string, handler dns.HandlerFunc) (addr string, stop func()) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	srv := &dns.Server{PacketConn: pc}
+	dns.HandleFunc(name, handler)
+
+	go srv.ActivateAndServe()
+
+	return pc.LocalAddr().String(), func() {
+		dns.HandleRemove(name)
+		srv.Shutdown()
+	}
+}
+
+func TestLookupDkimKey(t *testing.T) {
+	name := "selector._domainkey.example.com."
+	addr, stop := startFakeServer(t, name, []string{"v=DKIM1; k=ed25519; p=" + testEd25519Key})
+	defer stop()
+
+	r := &Resolver{
+		Client:  &dns.Client{Timeout: 2 * time.Second},
+		Servers: []string{addr},
+		MaxHops: DefaultMaxHops,
+	}
+
+	key, warnings := r.LookupDkimKey(context.Background(), "selector", "example.com")
+	if key.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", key.ParseError)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if key.K.Value != "ed25519" {
+		t.Errorf("k=: got %q, want ed25519", key.K.Value)
+	}
+}
+
+func TestLookupDkimKeyUnreachable(t *testing.T) {
+	r := &Resolver{
+		Client:  &dns.Client{Timeout: 2 * time.Second},
+		Servers: []string{"127.0.0.1:1"}, // nothing listening
+		MaxHops: DefaultMaxHops,
+	}
+
+	key, _ := r.LookupDkimKey(context.Background(), "selector", "example.com")
+	if key.ParseError.Message == "" {
+		t.Errorf("expected a parse error for an unreachable resolver")
+	}
+}
+
+func TestLookupDkimKeyNXDOMAIN(t *testing.T) {
+	name := "selector._domainkey.example.com."
+	addr, stop := startFakeServerFunc(t, name, func(w dns.ResponseWriter, req *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(req)
+		msg.Rcode = dns.RcodeNameError
+		w.WriteMsg(msg)
+	})
+	defer stop()
+
+	r := &Resolver{
+		Client:  &dns.Client{Timeout: 2 * time.Second},
+		Servers: []string{addr},
+		MaxHops: DefaultMaxHops,
+	}
+
+	key, _ := r.LookupDkimKey(context.Background(), "selector", "example.com")
+	if key.ParseError.Message == "" {
+		t.Errorf("expected a parse error for NXDOMAIN")
+	}
+}
+
+func TestLookupDkimKeyCNAMEHop(t *testing.T) {
+	name := "selector._domainkey.example.com."
+	target := "selector._domainkey.elsewhere.example."
+	addr, stop := startFakeServerFunc(t, name, func(w dns.ResponseWriter, req *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(req)
+		if req.Question[0].Qtype == dns.TypeTXT {
+			msg.Answer = append(msg.Answer, &dns.CNAME{
+				Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60},
+				Target: target,
+			})
+		}
+		w.WriteMsg(msg)
+	})
+	defer stop()
+	_, stopTarget := startFakeServer(t, target, []string{"v=DKIM1; k=ed25519; p=" + testEd25519Key})
+	defer stopTarget()
+
+	r := &Resolver{
+		Client:  &dns.Client{Timeout: 2 * time.Second},
+		Servers: []string{addr},
+		MaxHops: DefaultMaxHops,
+	}
+
+	key, warnings := r.LookupDkimKey(context.Background(), "selector", "example.com")
+	if key.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", key.ParseError)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "CNAME") {
+		t.Errorf("warnings: got %v, want a single CNAME warning", warnings)
+	}
+}
+
+func TestLookupDkimKeyMultipleTXT(t *testing.T) {
+	name := "selector._domainkey.example.com."
+	addr, stop := startFakeServerFunc(t, name, func(w dns.ResponseWriter, req *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(req)
+		if req.Question[0].Qtype == dns.TypeTXT {
+			for _, txt := range []string{"v=DKIM1; k=ed25519; p=" + testEd25519Key, "v=DKIM1; k=rsa; p="} {
+				msg.Answer = append(msg.Answer, &dns.TXT{
+					Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+					Txt: []string{txt},
+				})
+			}
+		}
+		w.WriteMsg(msg)
+	})
+	defer stop()
+
+	r := &Resolver{
+		Client:  &dns.Client{Timeout: 2 * time.Second},
+		Servers: []string{addr},
+		MaxHops: DefaultMaxHops,
+	}
+
+	key, _ := r.LookupDkimKey(context.Background(), "selector", "example.com")
+	if key.ParseError.Message == "" {
+		t.Errorf("expected a parse error for multiple TXT records")
+	}
+}
+
+// testEd25519Key is an arbitrary 32-byte ed25519 SubjectPublicKeyInfo,
+// base64 encoded, used only to exercise the DKIM key parser
+const testEd25519Key = "MCowBQYDK2VwAyEAY4YnxR8gfu9Bm2bWL+coyVdR1zYT5EHqTXY1lLdhWmg="
+
+func TestLookupBimiRecord(t *testing.T) {
+	logo := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`))
+	}))
+	defer logo.Close()
+
+	name := "default._bimi.example.com."
+	addr, stop := startFakeServer(t, name, []string{"v=BIMI1; l=" + logo.URL + "/logo.svg;"})
+	defer stop()
+
+	r := &Resolver{
+		Client:     &dns.Client{Timeout: 2 * time.Second},
+		HTTPClient: logo.Client(),
+		Servers:    []string{addr},
+		MaxHops:    DefaultMaxHops,
+	}
+
+	record, warnings := r.LookupBimiRecord(context.Background(), "", "example.com")
+	if record.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", record.ParseError)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if len(record.L.Errors) != 0 {
+		t.Errorf("unexpected errors on l=: %v", record.L.Errors)
+	}
+}
+
+func TestLookupBimiRecordLogoUnreachable(t *testing.T) {
+	name := "default._bimi.example.net."
+	addr, stop := startFakeServer(t, name, []string{"v=BIMI1; l=https://127.0.0.1:1/logo.svg;"})
+	defer stop()
+
+	r := &Resolver{
+		Client:     &dns.Client{Timeout: 2 * time.Second},
+		HTTPClient: &http.Client{Timeout: 2 * time.Second},
+		Servers:    []string{addr},
+		MaxHops:    DefaultMaxHops,
+	}
+
+	record, _ := r.LookupBimiRecord(context.Background(), "", "example.net")
+	if len(record.L.Errors) == 0 {
+		t.Errorf("expected an error annotation on l= when the logo can't be fetched")
+	}
+}
@@ -0,0 +1,223 @@
+package tagvalue
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ItemIterator parses a rfc 6376 tag=value input from an io.Reader,
+// producing one Item at a time without buffering the whole input in
+// memory. This is useful for large inputs, such as ARC-Seal chains,
+// or when annotating many records in a batch
+type ItemIterator struct {
+	r   *bufio.Reader
+	pos int
+	err error
+}
+
+// NewItemIterator returns an ItemIterator reading from r
+func NewItemIterator(r io.Reader) *ItemIterator {
+	return &ItemIterator{r: bufio.NewReader(r)}
+}
+
+// Err returns the first error encountered by the iterator, if any.
+// It should be checked after Next returns false
+func (it *ItemIterator) Err() error {
+	return it.err
+}
+
+// Next returns the next Item in the input, and true. At the end of
+// the input, or on error, it returns false; the error, if any, is
+// available from Err
+func (it *ItemIterator) Next() (Item, bool) {
+	if it.err != nil {
+		return Item{}, false
+	}
+
+	if err := it.skipOptionalFws(); err != nil {
+		it.err = err
+		return Item{}, false
+	}
+
+	var item Item
+	item.TagPos = it.pos
+	r, w, rerr := it.r.ReadRune()
+	if rerr == io.EOF {
+		return Item{}, false
+	}
+	if rerr != nil {
+		it.err = rerr
+		return Item{}, false
+	}
+	it.pos += w
+	if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+		it.err = ParseError{"expecting alpha character in tag", it.pos}
+		return Item{}, false
+	}
+
+	var tag strings.Builder
+	tag.WriteRune(r)
+	it.acceptTagRun(&tag)
+	item.Tag = tag.String()
+
+	if err := it.skipOptionalFws(); err != nil {
+		it.err = err
+		return Item{}, false
+	}
+
+	r, w, rerr = it.r.ReadRune()
+	if rerr != nil || r != '=' {
+		it.err = ParseError{"expecting '='", it.pos}
+		return Item{}, false
+	}
+	it.pos += w
+
+	if err := it.skipOptionalFws(); err != nil {
+		it.err = err
+		return Item{}, false
+	}
+	item.ValuePos = it.pos
+
+	var value strings.Builder
+	for {
+		r, w, rerr := it.r.ReadRune()
+		if rerr == nil && r != ';' && r >= '!' && r <= '~' {
+			value.WriteRune(r)
+			it.pos += w
+			continue
+		}
+		if rerr == nil {
+			if err := it.r.UnreadRune(); err != nil {
+				it.err = err
+				return Item{}, false
+			}
+		} else if rerr != io.EOF {
+			it.err = rerr
+			return Item{}, false
+		}
+
+		var fws strings.Builder
+		if err := it.acceptOptionalFws(&fws); err != nil {
+			it.err = err
+			return Item{}, false
+		}
+
+		r2, w2, rerr2 := it.r.ReadRune()
+		if rerr2 == io.EOF || (rerr2 == nil && r2 == ';') {
+			if rerr2 == nil {
+				it.pos += w2
+			}
+			item.Value = value.String()
+			return item, true
+		}
+		if rerr2 != nil {
+			it.err = rerr2
+			return Item{}, false
+		}
+		value.WriteString(fws.String())
+		value.WriteRune(r2)
+		it.pos += w2
+	}
+}
+
+// acceptTagRun consumes alphamerics plus underscore into buf
+func (it *ItemIterator) acceptTagRun(buf *strings.Builder) {
+	for {
+		b, err := it.r.Peek(1)
+		if err != nil {
+			return
+		}
+		c := b[0]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			it.r.Discard(1)
+			it.pos++
+			buf.WriteByte(c)
+			continue
+		}
+		return
+	}
+}
+
+// skipOptionalFws consumes an optional folding whitespace, discarding it
+func (it *ItemIterator) skipOptionalFws() error {
+	return it.acceptOptionalFws(nil)
+}
+
+// acceptOptionalFws consumes an optional folding whitespace, appending
+// whatever it consumes to buf if buf is non-nil
+func (it *ItemIterator) acceptOptionalFws(buf *strings.Builder) error {
+	for {
+		b, err := it.r.Peek(1)
+		if err != nil || (b[0] != ' ' && b[0] != '\t') {
+			break
+		}
+		it.r.Discard(1)
+		it.pos++
+		if buf != nil {
+			buf.WriteByte(b[0])
+		}
+	}
+
+	b, err := it.r.Peek(2)
+	if err != nil || b[0] != '\r' || b[1] != '\n' {
+		return nil
+	}
+
+	b2, err := it.r.Peek(3)
+	if err != nil || (b2[2] != ' ' && b2[2] != '\t') {
+		return errors.New("malformed folding whitespace")
+	}
+
+	it.r.Discard(2)
+	it.pos += 2
+	if buf != nil {
+		buf.WriteString("\r\n")
+	}
+	for {
+		b, err := it.r.Peek(1)
+		if err != nil || (b[0] != ' ' && b[0] != '\t') {
+			return nil
+		}
+		it.r.Discard(1)
+		it.pos++
+		if buf != nil {
+			buf.WriteByte(b[0])
+		}
+	}
+}
+
+// StreamItems parses r in a background goroutine, returning a channel
+// of Items and a channel that will receive at most one error. The
+// items channel is closed when the input is exhausted or an error
+// occurs. If ctx is cancelled before the input is exhausted, the
+// goroutine stops at its next send and closes the items channel; an
+// Item that a caller is still receiving at that moment may be
+// delivered first. Callers that abandon the channel before it's
+// drained must cancel ctx to avoid leaking the goroutine
+func StreamItems(ctx context.Context, r io.Reader) (<-chan Item, <-chan error) {
+	items := make(chan Item)
+	errs := make(chan error, 1)
+	it := NewItemIterator(r)
+	go func() {
+		defer close(items)
+		defer close(errs)
+		for {
+			item, ok := it.Next()
+			if !ok {
+				if err := it.Err(); err != nil {
+					errs <- err
+				}
+				return
+			}
+			select {
+			case items <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return items, errs
+}
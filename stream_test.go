@@ -0,0 +1,63 @@
+package tagvalue
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestItemIterator(t *testing.T) {
+	it := NewItemIterator(strings.NewReader(sig1))
+	var items []Item
+	for {
+		item, ok := it.Next()
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("sig1 stream parse failed: %v", err)
+	}
+
+	want, err := NewTagValue(sig1)
+	if err != nil {
+		t.Fatalf("sig1 parse failed: %v", err)
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i := range want {
+		if items[i] != want[i] {
+			t.Errorf("item %d: got %#v, want %#v", i, items[i], want[i])
+		}
+	}
+}
+
+// TestStreamItemsCancel checks that cancelling the context passed to
+// StreamItems lets its background goroutine exit even if the caller
+// stops draining the items channel early
+func TestStreamItemsCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	items, errs := StreamItems(ctx, strings.NewReader(sig1))
+	<-items // take one item, then abandon the channel
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := <-errs; !ok {
+			break
+		}
+	}
+	for time.Now().Before(deadline) {
+		if runtime.NumGoroutine() <= before {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("goroutine leaked: %d running, started with %d", runtime.NumGoroutine(), before)
+}
@@ -0,0 +1,58 @@
+package tagvalue
+
+// Sanity check SMTP TLS reporting policies for compliance with RFC 8460
+
+// TlsRpt represents an SMTP TLS-RPT record, as published to DNS at
+// _smtp._tls.<domain>, in a format intended for diagnostics and display
+type TlsRpt struct {
+	V            Field
+	Rua          Field
+	Unrecognized map[string]Field
+	ParseError   ParseError
+}
+
+func NewTlsRptRecord(input string) TlsRpt {
+	Fields, err := NewMap(input)
+	if err != nil {
+		switch v := err.(type) {
+		case ParseError:
+			return TlsRpt{ParseError: v}
+		default:
+			return TlsRpt{ParseError: ParseError{Message: err.Error()}}
+		}
+	}
+
+	ret := TlsRpt{
+		V:            Fields["v"],
+		Rua:          Fields["rua"],
+		Unrecognized: Fields,
+	}
+	for _, k := range []string{"v", "rua"} {
+		delete(ret.Unrecognized, k)
+	}
+
+	// Annotate version
+	if ret.V.Defined {
+		if ret.V.Value != "TLSRPTv1" {
+			ret.V.addError(`The version field must be <a href="https://tools.wordtothewise.com/rfc/8460#section-3">TLSRPTv1</a>`)
+		}
+		if ret.V.Index != 0 {
+			ret.V.addError(`The version tag must be the <a href="https://tools.wordtothewise.com/rfc/8460#section-3">first tag in the record</a>`)
+		}
+	} else {
+		ret.V.addError(`TLS-RPT records must have a <a href="https://tools.wordtothewise.com/rfc/8460#section-3">version field</a>`)
+	}
+
+	// Annotate reporting URIs
+	if ret.Rua.Defined {
+		for _, uri := range splitURIList(ret.Rua.Value) {
+			if !isValidReportURI(uri) {
+				ret.Rua.addError(`'` + uri + `' isn't a <a href="https://tools.wordtothewise.com/rfc/8460#section-3">valid report URI</a>, expected mailto: or https:`)
+			}
+		}
+	} else {
+		ret.Rua.addError(`TLS-RPT records must have a <a href="https://tools.wordtothewise.com/rfc/8460#section-3">rua field</a>`)
+	}
+
+	return ret
+}
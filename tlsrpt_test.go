@@ -0,0 +1,23 @@
+package tagvalue
+
+import "testing"
+
+func TestNewTlsRptRecord(t *testing.T) {
+	rec := NewTlsRptRecord("v=TLSRPTv1; rua=mailto:reports@example.com")
+	if rec.ParseError.Message != "" {
+		t.Fatalf("unexpected parse error: %v", rec.ParseError)
+	}
+	if len(rec.V.Errors) != 0 {
+		t.Errorf("unexpected errors on v=: %v", rec.V.Errors)
+	}
+	if len(rec.Rua.Errors) != 0 {
+		t.Errorf("unexpected errors on rua=: %v", rec.Rua.Errors)
+	}
+}
+
+func TestNewTlsRptRecordMissingRua(t *testing.T) {
+	rec := NewTlsRptRecord("v=TLSRPTv1")
+	if len(rec.Rua.Errors) == 0 {
+		t.Errorf("expected an error for a missing rua field")
+	}
+}
@@ -0,0 +1,23 @@
+package tagvalue
+
+import "strings"
+
+// splitURIList splits a comma separated list of report URIs, as used
+// by DMARC's rua/ruf and TLS-RPT's rua tags, into its component URIs.
+// Each URI may carry an optional "!<size>" byte limit suffix per
+// RFC 7489 section 6.2.
+func splitURIList(value string) []string {
+	parts := strings.Split(value, ",")
+	uris := make([]string, len(parts))
+	for i, p := range parts {
+		uris[i] = strings.TrimSpace(p)
+	}
+	return uris
+}
+
+// isValidReportURI reports whether uri (with any trailing "!<size>"
+// stripped) uses a scheme suitable for aggregate or failure reports
+func isValidReportURI(uri string) bool {
+	uri, _, _ = strings.Cut(uri, "!")
+	return strings.HasPrefix(uri, "mailto:") || strings.HasPrefix(uri, "https://")
+}